@@ -0,0 +1,40 @@
+package echo
+
+import "tinygo.org/x/drivers/at24cx"
+
+// at24SlotCount bounds how many distinct tank names an AT24Store can back
+// without hashing two names into the same slot.
+const at24SlotCount = 16
+
+// AT24Store is a CalibrationStore backed by an AT24Cxx-family I2C EEPROM.
+// Each tank's record is kept in its own slot, hashed from its name, within
+// a fixed-size region starting at baseOffset.
+type AT24Store struct {
+	dev        at24cx.Device
+	baseOffset int64
+}
+
+// NewAT24Store returns an AT24Store whose records live in dev starting at baseOffset.
+func NewAT24Store(dev at24cx.Device, baseOffset int64) *AT24Store {
+	return &AT24Store{dev: dev, baseOffset: baseOffset}
+}
+
+// slotOffset returns the byte offset of name's record.
+func (s *AT24Store) slotOffset(name string) int64 {
+	return s.baseOffset + slotFor(name, at24SlotCount)*recordSize
+}
+
+// Load reads and validates name's calibration record from the EEPROM.
+func (s *AT24Store) Load(name string) (fullDist, emptyDist int32, err error) {
+	buf := make([]byte, recordSize)
+	if _, err := s.dev.ReadAt(buf, s.slotOffset(name)); err != nil {
+		return 0, 0, err
+	}
+	return decodeRecord(name, buf)
+}
+
+// Save writes name's calibration record to the EEPROM.
+func (s *AT24Store) Save(name string, fullDist, emptyDist int32) error {
+	_, err := s.dev.WriteAt(encodeRecord(name, fullDist, emptyDist), s.slotOffset(name))
+	return err
+}
@@ -0,0 +1,62 @@
+package echo
+
+import "math"
+
+// EnvSensor supplies the ambient readings needed to compensate an HC-SR04's
+// time-of-flight reading for the local speed of sound. Implementations are
+// expected to wrap something like a BME280 or SHT3x. Any method may return
+// an error (e.g. sensor not yet warmed up); readings() falls back to
+// standard conditions for whichever reading is unavailable.
+type EnvSensor interface {
+	Temperature() (tempC float64, err error)       // degrees Celsius
+	Humidity() (relHumidityPct float64, err error) // percent relative humidity, 0-100
+	Pressure() (pressureKPa float64, err error)    // kilopascals
+}
+
+// standard atmosphere, used when a tank has no EnvSensor configured or a
+// reading fails
+const (
+	stdTempC       = 20.0
+	stdHumidityPct = 50.0
+	stdPressureKPa = 101.325
+)
+
+// speedOfSound estimates the speed of sound in air in meters/second from
+// temperature, relative humidity, and barometric pressure, using a dry-air
+// baseline (Cramer 1993: 331.4 + 0.6*T) with a first-order correction for
+// the extra speed humid air carries relative to dry air at the same
+// pressure.
+func speedOfSound(tempC, humidityPct, pressureKPa float64) float64 {
+	c := 331.4 + 0.6*tempC
+
+	// saturation vapor pressure of water at tempC (kPa), Magnus-Tetens approximation
+	psat := 0.61094 * math.Exp(17.625*tempC/(tempC+243.04))
+
+	// partial pressure of water vapor actually present, as a share of total pressure
+	pv := (humidityPct / 100.0) * psat
+	xw := pv / pressureKPa
+
+	c += 32.0 * xw
+
+	return c
+}
+
+// readings pulls temperature, humidity, and pressure from the tank's
+// EnvSensor, substituting standard-atmosphere values for any reading that is
+// unavailable or whose sensor was never configured.
+func (t *tank) readings() (tempC, humidityPct, pressureKPa float64) {
+	tempC, humidityPct, pressureKPa = stdTempC, stdHumidityPct, stdPressureKPa
+	if t.env == nil {
+		return
+	}
+	if v, err := t.env.Temperature(); err == nil {
+		tempC = v
+	}
+	if v, err := t.env.Humidity(); err == nil {
+		humidityPct = v
+	}
+	if v, err := t.env.Pressure(); err == nil {
+		pressureKPa = v
+	}
+	return
+}
@@ -0,0 +1,32 @@
+package echo
+
+import (
+	"math"
+	"testing"
+)
+
+// TestSpeedOfSound checks a couple of reference points against the Cramer
+// dry-air baseline plus humidity correction, so a future edit to the
+// formula can't silently drift the temperature-compensated conversion Read
+// relies on.
+func TestSpeedOfSound(t *testing.T) {
+	cases := []struct {
+		name        string
+		tempC       float64
+		humidityPct float64
+		pressureKPa float64
+		want        float64
+	}{
+		{"standard atmosphere", stdTempC, stdHumidityPct, stdPressureKPa, 343.7684682947899},
+		{"freezing and bone dry", 0.0, 0.0, 101.325, 331.4},
+		{"hot and humid", 35.0, 90.0, 100.0, 354.0178599638504},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := speedOfSound(c.tempC, c.humidityPct, c.pressureKPa)
+			if math.Abs(got-c.want) > 1e-9 {
+				t.Errorf("speedOfSound(%v, %v, %v) = %v, want %v", c.tempC, c.humidityPct, c.pressureKPa, got, c.want)
+			}
+		})
+	}
+}
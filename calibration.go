@@ -0,0 +1,114 @@
+package echo
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrCorruptRecord is returned by a CalibrationStore when a stored record
+// fails its CRC-16 check, e.g. because a write was interrupted mid-page.
+var ErrCorruptRecord = errors.New("calibration record failed crc check")
+
+// ErrSlotCollision is returned by a CalibrationStore when a record is found
+// and passes its CRC-16 check, but was written for a different tank name
+// that happens to hash to the same slot. Without this check the store would
+// silently hand back another tank's calibration.
+var ErrSlotCollision = errors.New("calibration slot holds another tank's record")
+
+// CalibrationStore persists a tank's full/empty calibration distances so
+// they survive a power cycle. name is the tank's name, allowing a single
+// store to back several tanks.
+type CalibrationStore interface {
+	Load(name string) (fullDist, emptyDist int32, err error)
+	Save(name string, fullDist, emptyDist int32) error
+}
+
+// Option configures optional Tank behavior at construction time.
+type Option func(*tank)
+
+// WithCalibrationStore attaches a CalibrationStore the Tank will use to
+// restore its calibration in Configure and persist it on every Calibrate.
+func WithCalibrationStore(s CalibrationStore) Option {
+	return func(t *tank) {
+		t.store = s
+	}
+}
+
+// recordSize is the on-the-wire size of an encoded calibration record:
+// fullDist (4 bytes) + emptyDist (4 bytes) + name tag (4 bytes) + CRC-16 (2 bytes).
+const recordSize = 14
+
+// encodeRecord packs fullDist and emptyDist into a fixed-size record tagged
+// with a hash of name and a trailing CRC-16, so a backend can detect both a
+// half-written page and a slot collision between two different tank names.
+func encodeRecord(name string, fullDist, emptyDist int32) []byte {
+	buf := make([]byte, recordSize)
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(fullDist))
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(emptyDist))
+	binary.LittleEndian.PutUint32(buf[8:12], fnv32(name))
+	binary.LittleEndian.PutUint16(buf[12:14], crc16(buf[0:12]))
+	return buf
+}
+
+// decodeRecord validates and unpacks a record written by encodeRecord for
+// name, returning ErrCorruptRecord if the CRC-16 doesn't match, or
+// ErrSlotCollision if the record's name tag belongs to a different tank
+// that hashed to the same slot.
+func decodeRecord(name string, buf []byte) (fullDist, emptyDist int32, err error) {
+	if len(buf) < recordSize {
+		return 0, 0, ErrCorruptRecord
+	}
+	if binary.LittleEndian.Uint16(buf[12:14]) != crc16(buf[0:12]) {
+		return 0, 0, ErrCorruptRecord
+	}
+	if binary.LittleEndian.Uint32(buf[8:12]) != fnv32(name) {
+		return 0, 0, ErrSlotCollision
+	}
+	fullDist = int32(binary.LittleEndian.Uint32(buf[0:4]))
+	emptyDist = int32(binary.LittleEndian.Uint32(buf[4:8]))
+	return fullDist, emptyDist, nil
+}
+
+// crc16 computes a CRC-16/MODBUS checksum (poly 0xA001 reflected, seeded
+// with 0xFFFF), chosen for its small, table-free implementation rather than
+// throughput.
+func crc16(data []byte) uint16 {
+	var crc uint16 = 0xFFFF
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = (crc >> 1) ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+// slotFor hashes name into [0, slotCount) so a CalibrationStore backend with
+// a fixed-size region can keep each tank's record in its own slot instead of
+// all tanks clobbering one shared offset. Any two names can collide, and
+// collisions grow likely as the number of distinct names approaches
+// slotCount; decodeRecord's name tag
+// turns a collision into ErrSlotCollision instead of a silently wrong read,
+// but callers backing a large fleet from one store should still size
+// slotCount accordingly to avoid losing calibrations to it.
+func slotFor(name string, slotCount int) int64 {
+	return int64(fnv32(name) % uint32(slotCount))
+}
+
+// fnv32 is a small, dependency-free string hash (FNV-1a).
+func fnv32(s string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= prime32
+	}
+	return h
+}
@@ -0,0 +1,46 @@
+package echo
+
+import "testing"
+
+func TestCRC16DistinctAndDeterministic(t *testing.T) {
+	a := crc16([]byte("tank-one"))
+	b := crc16([]byte("tank-two"))
+	if a == b {
+		t.Errorf("crc16 collided for distinct inputs: %d", a)
+	}
+	if crc16([]byte("tank-one")) != a {
+		t.Errorf("crc16 is not deterministic")
+	}
+}
+
+func TestEncodeDecodeRecordRoundTrip(t *testing.T) {
+	buf := encodeRecord("my tank", 100, 500)
+	full, empty, err := decodeRecord("my tank", buf)
+	if err != nil {
+		t.Fatalf("decodeRecord returned unexpected error: %v", err)
+	}
+	if full != 100 || empty != 500 {
+		t.Errorf("decodeRecord = (%d, %d), want (100, 500)", full, empty)
+	}
+}
+
+func TestDecodeRecordDetectsCorruption(t *testing.T) {
+	buf := encodeRecord("my tank", 100, 500)
+	buf[0] ^= 0xFF
+	if _, _, err := decodeRecord("my tank", buf); err != ErrCorruptRecord {
+		t.Errorf("decodeRecord on a torn write = %v, want ErrCorruptRecord", err)
+	}
+}
+
+func TestDecodeRecordDetectsSlotCollision(t *testing.T) {
+	buf := encodeRecord("tank a", 100, 500)
+	if _, _, err := decodeRecord("tank b", buf); err != ErrSlotCollision {
+		t.Errorf("decodeRecord for a different tank name = %v, want ErrSlotCollision", err)
+	}
+}
+
+func TestSlotForIsDeterministic(t *testing.T) {
+	if slotFor("my tank", 16) != slotFor("my tank", 16) {
+		t.Errorf("slotFor is not deterministic for the same name")
+	}
+}
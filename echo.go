@@ -7,9 +7,9 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/eyelight/trigger"
-	"tinygo.org/x/drivers/hcsr04"
 )
 
 const (
@@ -18,14 +18,15 @@ const (
 	ERR_EMPTY_CALIBRATION_FAILED = "calibration failed - 'empty' would become shallower than 'full'"
 )
 
-const pi float64 = 3.14159
-
 type TankShape int
 
 const (
 	Cylinder TankShape = iota
 	Cuboid
 	Sphere
+	HorizontalCylinder
+	Cone
+	Frustum
 )
 
 // String returns the string value of a TankShape
@@ -35,163 +36,123 @@ func (t TankShape) String() string {
 		return "Cuboid"
 	case Sphere:
 		return "Sphere"
+	case HorizontalCylinder:
+		return "HorizontalCylinder"
+	case Cone:
+		return "Cone"
+	case Frustum:
+		return "Frustum"
 	default: // Cylinder
 		return "Cylinder"
 	}
 }
 
-type LengthUnit int // in which tank dimensions are specified in a TankConfig
-
-const (
-	Centimeter LengthUnit = iota
-	Millimeter
-	Meter
-	Inch
-	Foot
-)
-
-// String returns the string value of a LengthUnit
-func (l LengthUnit) String() string {
-	switch l {
-	case Millimeter:
-		return "mm"
-	case Meter:
-		return "m"
-	case Inch:
-		return "in"
-	case Foot:
-		return "ft"
-	default: // Centimeter
-		return "cm"
-	}
-}
-
-// conv returns a conversion factor from a LengthUnit into centimeters which echo uses internally
-func (l LengthUnit) conv() float64 {
-	switch l {
-	case Millimeter:
-		return 0.1
-	case Meter:
-		return 100.0
-	case Inch:
-		return 2.54
-	case Foot:
-		return 30.48
-	default: // Centimeter
-		return 1.0
-	}
-}
-
-type VolumeUnit int // in which volumetric value is returned to callers of Read()
-
-const (
-	Milliliter VolumeUnit = iota // aka cubic centimeter
-	Liter
-	Ounce
-	Pint
-	Quart
-	Gallon
-)
-
-func (v VolumeUnit) String() string {
-	switch v {
-	case Liter:
-		return "L"
-	case Ounce:
-		return "oz"
-	case Pint:
-		return "pt"
-	case Quart:
-		return "qt"
-	case Gallon:
-		return "gal"
-	default: // Milliliter
-		return "mL"
-	}
-}
-
-// conv returns the conversion factor from the default of mL to a VolumeUnit
-func (v VolumeUnit) conv() float64 {
-	switch v {
-	case Liter:
-		return 0.001
-	case Ounce:
-		return 0.0338
-	case Pint:
-		return 0.002113
-	case Quart:
-		return 0.001057
-	case Gallon:
-		return 0.000264172
-	default:
-		return 1.0
-	}
-}
-
 type tank struct {
-	d         *hcsr04.Device
+	trig      machine.Pin
+	echo      machine.Pin
+	env       EnvSensor        // optional; enables temperature/humidity/pressure-compensated readings
+	store     CalibrationStore // optional; persists calibration across power cycles
 	name      string
 	shape     TankShape
-	lu        LengthUnit // length unit preferred by the consumer
-	vu        VolumeUnit // volume unit preferred by the consumer
-	r         float64    // radius in centimeters (use for spheroid & cylinder tanks)
-	h         float64    // height in centimeters (use for spheroid & cylinder tanks)
-	s1        float64    // side1 in centimeters (use for cuboid tanks)
-	s2        float64    // side2 in centimeters (used for cuboid tanks)
-	fullDist  int32      // calibratable distance representing 'full'
-	emptyDist int32      // calibratable distance representing 'empty'
+	r         Length  // radius (use for spheroid, cylinder & horizontal-cylinder tanks)
+	h         Length  // height (use for cylinder, cuboid, cone & frustum tanks)
+	s1        Length  // side1 (use for cuboid tanks)
+	s2        Length  // side2 (used for cuboid tanks)
+	length    Length  // axial length, used for HorizontalCylinder tanks
+	r1        Length  // top radius, used for Frustum tanks
+	r2        Length  // bottom radius, used for Frustum tanks
+	theta     float64 // apex half-angle in radians, used for Cone tanks
+	fullDist  int32   // calibratable distance representing 'full'
+	emptyDist int32   // calibratable distance representing 'empty'
+
+	sampleCount  int           // number of distance samples Read takes per call
+	sampleDelay  time.Duration // pause between samples
+	filter       FilterKind    // how samples are aggregated into one reading
+	lastVariance float64       // variance (mm^2) of the most recent Read's surviving samples
 }
 
 type TankConf struct {
-	name       string     // a nickname for the tank
-	shape      TankShape  // the shape of the tank dictates the distance-to-volume conversion
-	lengthUnit LengthUnit // preferred units of r, h, s1, and s2, internally converted to centimeter
-	volumeUnit VolumeUnit // preferred units to which volume readings will be converted
-	r          uint32     // number in LengthUnit representing the radius of a cylinder or spherical tank
-	h          uint32     // number in LengthUnit representing the height of a tank
-	s1         uint32     // number in LengthUnit representing side1 of a cuboid tank
-	s2         uint32     // number in LengthUnit representing side2 of a cuboid tank
+	Name   string    // a nickname for the tank
+	Shape  TankShape // the shape of the tank dictates the distance-to-volume conversion
+	R      Length    // radius of a cylinder, spherical, or horizontal-cylinder tank
+	H      Length    // height of a tank
+	S1     Length    // side1 of a cuboid tank
+	S2     Length    // side2 of a cuboid tank
+	Length Length    // axial length of a horizontal-cylinder tank
+	R1     Length    // top radius of a frustum tank
+	R2     Length    // bottom radius of a frustum tank
+	Theta  float64   // apex half-angle in radians of a cone tank
+	Env    EnvSensor // optional sensor for temperature-compensated distance readings
+
+	SampleCount int           // number of distance samples Read takes per call; 0 means 1
+	SampleDelay time.Duration // pause between samples
+	Filter      FilterKind    // how samples are aggregated into one reading
 }
 
 type Tank interface {
-	Configure(TankConf)              // sets up a tank for calibration
-	Calibrate(bool) error            // false calibrates empty / true calibrates full
-	Execute(trigger.Trigger)         // stub to satisfy the trigger.Triggerable interface
-	Name() string                    // returns the tank's name to satisfy the trigger.Triggerable interface
-	Read() (float64, float64, error) // returns percentage full, contained volume as VolumeUnit units
-	String() string                  // describes the tank with its relevant information
+	Configure(TankConf)             // sets up a tank for calibration
+	Calibrate(bool) error           // false calibrates empty / true calibrates full
+	Execute(trigger.Trigger)        // stub to satisfy the trigger.Triggerable interface
+	Name() string                   // returns the tank's name to satisfy the trigger.Triggerable interface
+	Read() (float64, Volume, error) // returns percentage full, contained Volume; caller picks display units via Volume.As/Format
+	ReadRaw() []int32               // returns the raw distance samples (mm) Read would otherwise filter and aggregate
+	LastVariance() float64          // returns the sample variance (mm^2) from the most recent Read, e.g. to log why ErrUnstable was returned
+	String() string                 // describes the tank with its relevant information
 }
 
-// New returns an unconfigured Tank using the passed-in pins
-func New(trigger, echo machine.Pin) Tank {
-	dev := hcsr04.New(trigger, echo)
-	return &tank{
-		d: &dev,
+// New returns an unconfigured Tank using the passed-in pins, applying any Options given
+func New(trigger, echo machine.Pin, opts ...Option) Tank {
+	configurePins(trigger, echo)
+	t := &tank{
+		trig: trigger,
+		echo: echo,
+	}
+	for _, opt := range opts {
+		opt(t)
 	}
+	return t
 }
 
-// Configure sets up a Tank for use, defaulting to a Cylinder of size 0 Millimeters & reporting in Milliliters
+// Configure sets up a Tank for use, defaulting to a Cylinder of size 0
 func (t *tank) Configure(tc TankConf) {
-	if tc.name == "" {
+	if tc.Name == "" {
 		t.name = "MyTank"
 	} else {
-		t.name = tc.name
+		t.name = tc.Name
+	}
+	t.shape = tc.Shape
+	t.r = tc.R
+	t.h = tc.H
+	t.s1 = tc.S1
+	t.s2 = tc.S2
+	t.length = tc.Length
+	t.r1 = tc.R1
+	t.r2 = tc.R2
+	t.theta = tc.Theta
+	t.env = tc.Env
+	t.sampleCount = tc.SampleCount
+	if t.sampleCount < 1 {
+		t.sampleCount = 1
+	}
+	t.sampleDelay = tc.SampleDelay
+	t.filter = tc.Filter
+	if t.store != nil {
+		if full, empty, err := t.store.Load(t.name); err == nil {
+			t.fullDist = full
+			t.emptyDist = empty
+		}
 	}
-	t.shape = tc.shape
-	t.lu = tc.lengthUnit
-	t.vu = tc.volumeUnit
-	t.r = t.cm(tc.r)   // convert to centimeters for internal use
-	t.h = t.cm(tc.h)   // convert to centimeters for internal use
-	t.s1 = t.cm(tc.s1) // convert to centimeters for internal use
-	t.s2 = t.cm(tc.s2) // convert to centimeters for internal use
 }
 
 // Calibrate takes a bool indicating which distance to calibrate,
 // reads the current distance (mm), checks for sanity,
-// and updates the tank's fullDist or emptyDist;
-// TODO: it then notifies the eeprom of the change
+// updates the tank's fullDist or emptyDist, and persists the change to the
+// tank's CalibrationStore, if one is configured
 func (t *tank) Calibrate(full bool) error {
-	c := t.d.ReadDistance()
+	c, err := t.readDistance()
+	if err != nil {
+		return err
+	}
 	if full {
 		// ensure fullDist calibration has a reading below emptyDist
 		if c >= t.emptyDist {
@@ -207,14 +168,16 @@ func (t *tank) Calibrate(full bool) error {
 		// set emptyDist to the calibration reading
 		t.emptyDist = c
 	}
-	// TODO: notify eeprom of change
+	if t.store != nil {
+		return t.store.Save(t.name, t.fullDist, t.emptyDist)
+	}
 	return nil
 }
 
 // Execute performs an action sent via mqtt for which the tank is the target
 func (t *tank) Execute(trigger.Trigger) {
 	// TODO: implement actions
-	// TODO: notify eeprom if action needs persistence
+	// TODO: notify the CalibrationStore if an action needs persistence
 }
 
 // Name returns the tank's name to comply with trigger.Triggerable
@@ -222,17 +185,26 @@ func (t *tank) Name() string {
 	return t.name
 }
 
-// Read returns a percentage full and volumetric measurement in the preferred VolumeUnits,
-// a string of which is also returned as the error value.
-// A return of -420.69 indicates an uncalibrated tank; the user should be prompted to calibrate
-func (t *tank) Read() (float64, float64, error) {
+// Read takes the tank's configured SampleCount of distance readings, rejects
+// outliers, aggregates the survivors per its Filter, and returns a
+// percentage full and the contained Volume; the caller picks a display unit
+// at print time via Volume.As or Volume.Format.
+// A return of -420.69 accompanies every error; with ERR_CALIBRATION_REQUIRED it
+// indicates an uncalibrated tank, and the user should be prompted to calibrate.
+// ErrUnstable indicates the tank is too unsettled (e.g. sloshing) to trust the reading.
+// ErrPingTimeout indicates the sensor itself never returned an echo (out of
+// range, disconnected pin, dead sensor) rather than a sloshing tank.
+func (t *tank) Read() (float64, Volume, error) {
 	if t.emptyDist == 0 || t.fullDist == 0 {
 		return -420.69, -420.69, errors.New(ERR_CALIBRATION_REQUIRED)
 	}
-	r := t.d.ReadDistance()
-	pct := float64((t.emptyDist - r) / (t.emptyDist - t.fullDist))
-	ml := t.ml(r)
-	return pct, ml, errors.New(t.vu.String())
+	r, err := t.filteredDistance()
+	if err != nil {
+		return -420.69, -420.69, err
+	}
+	pct := float64(t.emptyDist-r) / float64(t.emptyDist-t.fullDist)
+	v := t.volume(r)
+	return pct, v, nil
 }
 
 // String returns a string with relevant information about a tank
@@ -244,48 +216,75 @@ func (t *tank) String() string {
 	switch t.shape {
 	case Cuboid:
 		ss.WriteString(" Length: ")
-		ss.WriteString(strconv.FormatFloat(t.s1/t.lu.conv(), 'f', 2, 64))
-		ss.WriteString(t.lu.String())
+		ss.WriteString(t.s1.Format(Centimeter))
 		ss.WriteString(" Width: ")
-		ss.WriteString(strconv.FormatFloat(t.s2/t.lu.conv(), 'f', 2, 64))
-		ss.WriteString(t.lu.String())
+		ss.WriteString(t.s2.Format(Centimeter))
 	case Cylinder:
 		ss.WriteString(" Height: ")
-		ss.WriteString(strconv.FormatFloat(t.h/t.lu.conv(), 'f', 2, 64))
-		ss.WriteString(t.lu.String())
+		ss.WriteString(t.h.Format(Centimeter))
 		ss.WriteString(" Radius: ")
-		ss.WriteString(strconv.FormatFloat(t.r/t.lu.conv(), 'f', 2, 64))
-		ss.WriteString(t.lu.String())
+		ss.WriteString(t.r.Format(Centimeter))
 	case Sphere:
 		ss.WriteString(" Radius: ")
-		ss.WriteString(strconv.FormatFloat(t.r/t.lu.conv(), 'f', 2, 64))
-		ss.WriteString(t.lu.String())
+		ss.WriteString(t.r.Format(Centimeter))
+	case HorizontalCylinder:
+		ss.WriteString(" Length: ")
+		ss.WriteString(t.length.Format(Centimeter))
+		ss.WriteString(" Radius: ")
+		ss.WriteString(t.r.Format(Centimeter))
+	case Cone:
+		ss.WriteString(" Height: ")
+		ss.WriteString(t.h.Format(Centimeter))
+		ss.WriteString(" Apex Half-Angle: ")
+		ss.WriteString(strconv.FormatFloat(t.theta*180/math.Pi, 'f', 2, 64))
+		ss.WriteString("deg")
+	case Frustum:
+		ss.WriteString(" Height: ")
+		ss.WriteString(t.h.Format(Centimeter))
+		ss.WriteString(" Top Radius: ")
+		ss.WriteString(t.r1.Format(Centimeter))
+		ss.WriteString(" Bottom Radius: ")
+		ss.WriteString(t.r2.Format(Centimeter))
 	}
 	ss.WriteString(" Calibrated Capacity: ")
-	ss.WriteString(strconv.FormatFloat(t.ml(t.fullDist), 'f', 2, 64))
-	ss.WriteString(t.vu.String())
+	ss.WriteString(t.volume(t.fullDist).Format(Milliliter))
+	if name, confidence := MatchPreset(t); name != "" {
+		ss.WriteString(" Closest Preset: ")
+		ss.WriteString(name)
+		ss.WriteString(" (")
+		ss.WriteString(strconv.FormatFloat(confidence*100, 'f', 0, 64))
+		ss.WriteString("% match)")
+	}
 	return ss.String()
 }
 
-// ml returns a milliliter value indicating tank volume from a passed-in distance reading
-func (t *tank) ml(mm int32) float64 {
-	cm := float64((t.emptyDist - mm) / 10)
+// volume returns the tank's contained Volume from a passed-in distance reading
+func (t *tank) volume(mm int32) Volume {
+	cm := float64(t.emptyDist-mm) / 10.0
+	r, s1, s2 := float64(t.r), float64(t.s1), float64(t.s2)
 	var ml float64
 	switch t.shape {
 	case Cylinder:
 		// cubic centimeter (mL) volume of a cylinder = pi * r^2 * h
-		ml = math.Pow(t.r, 2.0) * pi * cm
+		ml = math.Pow(r, 2.0) * math.Pi * cm
 	case Sphere:
-		// spherical cap in cubic cm (mL) = (1/6)pi * h * (3r^2 + h^2)
-		ml = (1 / 6) * pi * cm * (3*math.Pow(t.r, 2) + math.Pow(cm, 2))
+		// spherical cap in cubic cm (mL) = (pi/6)h(3r^2 + h^2)
+		ml = (math.Pi / 6) * cm * (3*math.Pow(r, 2) + math.Pow(cm, 2))
 	case Cuboid:
 		// cuboid volume in cubic cm (mL) = l * w * h
-		ml = t.s1 * t.s2 * cm
+		ml = s1 * s2 * cm
+	case HorizontalCylinder:
+		// partial-fill volume of a cylinder lying on its side, h = liquid depth
+		length := float64(t.length)
+		ml = length * (math.Pow(r, 2)*math.Acos((r-cm)/r) - (r-cm)*math.Sqrt(2*r*cm-math.Pow(cm, 2)))
+	case Cone:
+		// apex-down cone, V = pi*h^3*tan^2(theta)/3
+		ml = math.Pi * math.Pow(cm, 3) * math.Pow(math.Tan(t.theta), 2) / 3
+	case Frustum:
+		// V = (pi*h/3)(R2^2 + R2*r(h) + r(h)^2), r(h) linearly interpolated between top & bottom radii
+		r1, r2, height := float64(t.r1), float64(t.r2), float64(t.h)
+		rh := r2 + (r1-r2)*(cm/height)
+		ml = (math.Pi * cm / 3) * (math.Pow(r2, 2) + r2*rh + math.Pow(rh, 2))
 	}
-	return ml
-}
-
-// cm converts and returns in centimeters the passed in value converted from the tank's LengthUnit
-func (t *tank) cm(input uint32) float64 {
-	return t.lu.conv() * float64(input)
+	return Volume(ml)
 }
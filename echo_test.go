@@ -0,0 +1,63 @@
+package echo
+
+import (
+	"math"
+	"testing"
+)
+
+// TestVolume covers each TankShape's formula at a known fill height, so a
+// future edit (like the integer-division sphere-volume bug this backlog
+// fixed) can't silently zero out or mis-scale a shape's readings.
+func TestVolume(t *testing.T) {
+	cases := []struct {
+		name string
+		tank tank
+		mm   int32
+		want float64
+	}{
+		{
+			name: "cylinder",
+			tank: tank{shape: Cylinder, r: 10 * Centimeter, emptyDist: 200},
+			mm:   100,
+			want: 3141.5926535897934,
+		},
+		{
+			name: "sphere",
+			tank: tank{shape: Sphere, r: 10 * Centimeter, emptyDist: 200},
+			mm:   100,
+			want: 2094.395102393195,
+		},
+		{
+			name: "cuboid",
+			tank: tank{shape: Cuboid, s1: 10 * Centimeter, s2: 5 * Centimeter, emptyDist: 200},
+			mm:   100,
+			want: 500,
+		},
+		{
+			name: "horizontal cylinder",
+			tank: tank{shape: HorizontalCylinder, r: 10 * Centimeter, length: 20 * Centimeter, emptyDist: 100},
+			mm:   50,
+			want: 1228.3696986087568,
+		},
+		{
+			name: "cone",
+			tank: tank{shape: Cone, theta: math.Pi / 4, emptyDist: 200},
+			mm:   100,
+			want: 1047.1975511965973,
+		},
+		{
+			name: "frustum",
+			tank: tank{shape: Frustum, r1: 10 * Centimeter, r2: 5 * Centimeter, h: 20 * Centimeter, emptyDist: 200},
+			mm:   100,
+			want: 1243.5470920459597,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := float64(c.tank.volume(c.mm))
+			if math.Abs(got-c.want) > 1e-6 {
+				t.Errorf("volume() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
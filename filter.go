@@ -0,0 +1,190 @@
+package echo
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// FilterKind selects how Read() aggregates multiple distance samples into a single reading.
+type FilterKind int
+
+const (
+	Raw         FilterKind = iota // no aggregation; SampleCount should be 1
+	Median                        // median of the surviving samples
+	TrimmedMean                   // mean of the surviving samples after trimming the tails
+	MAD                           // median-absolute-deviation outlier rejection, then mean of survivors
+)
+
+// ErrUnstable is returned by Read when the sampled readings have enough
+// variance that the tank is very likely sloshing rather than settled.
+var ErrUnstable = errors.New("reading is unstable; tank appears to be sloshing")
+
+// kOutlier is how many median-absolute-deviations from the median a sample
+// may be before it's rejected as an outlier.
+const kOutlier = 3.0
+
+// unstableVariance is the sample variance (mm^2) above which Read returns ErrUnstable.
+const unstableVariance = 400.0
+
+// LastVariance returns the sample variance (mm^2) of the surviving samples
+// from the most recent Read, e.g. to log how close a reading came to
+// tripping ErrUnstable. It's zero until the first Read.
+func (t *tank) LastVariance() float64 {
+	return t.lastVariance
+}
+
+// ReadRaw collects the tank's configured SampleCount distance readings
+// (mm), pausing SampleDelay between each, without filtering or aggregation.
+// A sample whose ping times out is recorded as -1, which is always outside
+// a calibrated tank's [fullDist, emptyDist] bounds and so gets rejected as
+// an outlier downstream.
+// Callers doing their own DSP on HC-SR04 noise can use this directly instead of Read.
+func (t *tank) ReadRaw() []int32 {
+	n := t.sampleCount
+	if n < 1 {
+		n = 1
+	}
+	samples := make([]int32, n)
+	for i := range samples {
+		d, err := t.readDistance()
+		if err != nil {
+			d = -1
+		}
+		samples[i] = d
+		if i < n-1 && t.sampleDelay > 0 {
+			time.Sleep(t.sampleDelay)
+		}
+	}
+	return samples
+}
+
+// filteredDistance samples the tank per its configured SampleCount and
+// Filter, discarding readings outside [fullDist, emptyDist] and beyond
+// kOutlier MADs from the median, then aggregating the survivors. It also
+// records the survivors' variance so Read can flag a sloshing tank.
+func (t *tank) filteredDistance() (int32, error) {
+	samples := t.ReadRaw()
+	survivors := t.rejectOutliers(samples)
+	if len(survivors) == 0 {
+		return 0, noSurvivorsCause(samples)
+	}
+	t.lastVariance = variance(survivors)
+	if t.lastVariance > unstableVariance {
+		return 0, ErrUnstable
+	}
+	return t.aggregate(survivors), nil
+}
+
+// noSurvivorsCause explains why bounds-filtering left no samples to
+// aggregate. If every sample is -1, the HC-SR04 itself never returned an
+// echo (see ReadRaw), and reporting ErrUnstable would misdiagnose a dead or
+// disconnected sensor as a sloshing tank; propagate ErrPingTimeout instead.
+// Otherwise the samples came back but fell outside the tank's calibrated
+// range, which is the genuinely-unstable case.
+func noSurvivorsCause(samples []int32) error {
+	for _, s := range samples {
+		if s != -1 {
+			return ErrUnstable
+		}
+	}
+	return ErrPingTimeout
+}
+
+// rejectOutliers discards samples outside [fullDist, emptyDist] and beyond
+// kOutlier median-absolute-deviations from the median of what remains.
+func (t *tank) rejectOutliers(samples []int32) []int32 {
+	lo, hi := t.fullDist, t.emptyDist
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	inBounds := make([]int32, 0, len(samples))
+	for _, s := range samples {
+		if s >= lo && s <= hi {
+			inBounds = append(inBounds, s)
+		}
+	}
+	if len(inBounds) == 0 {
+		return inBounds
+	}
+
+	med := median(inBounds)
+	mad := medianAbsoluteDeviation(inBounds, med)
+	if mad == 0 {
+		return inBounds
+	}
+
+	survivors := make([]int32, 0, len(inBounds))
+	for _, s := range inBounds {
+		if math.Abs(float64(s-med)) <= kOutlier*mad {
+			survivors = append(survivors, s)
+		}
+	}
+	return survivors
+}
+
+// aggregate combines samples into a single distance reading per the tank's configured Filter.
+func (t *tank) aggregate(samples []int32) int32 {
+	switch t.filter {
+	case Median:
+		return median(samples)
+	case TrimmedMean:
+		return int32(trimmedMean(samples))
+	case MAD:
+		return int32(mean(samples))
+	default: // Raw
+		return samples[0]
+	}
+}
+
+// median returns the median of samples, without modifying samples.
+func median(samples []int32) int32 {
+	sorted := append([]int32(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// medianAbsoluteDeviation returns the median of samples' absolute deviations from med.
+func medianAbsoluteDeviation(samples []int32, med int32) float64 {
+	devs := make([]int32, len(samples))
+	for i, s := range samples {
+		devs[i] = int32(math.Abs(float64(s - med)))
+	}
+	return float64(median(devs))
+}
+
+// trimmedMean sorts samples and discards the smallest and largest 10% before averaging.
+func trimmedMean(samples []int32) float64 {
+	sorted := append([]int32(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	trim := len(sorted) / 10
+	if len(sorted)-2*trim < 1 {
+		trim = 0
+	}
+	return mean(sorted[trim : len(sorted)-trim])
+}
+
+// mean returns the arithmetic mean of samples.
+func mean(samples []int32) float64 {
+	var sum int64
+	for _, s := range samples {
+		sum += int64(s)
+	}
+	return float64(sum) / float64(len(samples))
+}
+
+// variance returns the population variance of samples.
+func variance(samples []int32) float64 {
+	m := mean(samples)
+	var sumSq float64
+	for _, s := range samples {
+		d := float64(s) - m
+		sumSq += d * d
+	}
+	return sumSq / float64(len(samples))
+}
@@ -0,0 +1,120 @@
+package echo
+
+import "testing"
+
+func TestMedian(t *testing.T) {
+	cases := []struct {
+		name    string
+		samples []int32
+		want    int32
+	}{
+		{"odd", []int32{5, 1, 3}, 3},
+		{"even averages the middle two", []int32{10, 20, 30, 40}, 25},
+		{"single", []int32{7}, 7},
+		{"tie at the middle", []int32{1, 2, 2, 3}, 2},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			in := append([]int32(nil), c.samples...)
+			if got := median(c.samples); got != c.want {
+				t.Errorf("median(%v) = %d, want %d", c.samples, got, c.want)
+			}
+			for i := range in {
+				if in[i] != c.samples[i] {
+					t.Errorf("median mutated its input: %v != %v", c.samples, in)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestMedianAbsoluteDeviation(t *testing.T) {
+	cases := []struct {
+		name    string
+		samples []int32
+		med     int32
+		want    float64
+	}{
+		{"typical spread", []int32{1, 2, 3, 4, 5}, 3, 1},
+		{"all identical is zero", []int32{42, 42, 42}, 42, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := medianAbsoluteDeviation(c.samples, c.med); got != c.want {
+				t.Errorf("medianAbsoluteDeviation(%v, %d) = %v, want %v", c.samples, c.med, got, c.want)
+			}
+		})
+	}
+}
+
+func TestTrimmedMean(t *testing.T) {
+	cases := []struct {
+		name    string
+		samples []int32
+		want    float64
+	}{
+		{"small slice falls back to untrimmed mean", []int32{1, 2, 3}, 2},
+		{"ten samples trims the low and high one", []int32{1, 2, 3, 4, 5, 6, 7, 8, 9, 100}, mean([]int32{2, 3, 4, 5, 6, 7, 8, 9})},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := trimmedMean(c.samples); got != c.want {
+				t.Errorf("trimmedMean(%v) = %v, want %v", c.samples, got, c.want)
+			}
+		})
+	}
+}
+
+func TestMean(t *testing.T) {
+	if got := mean([]int32{1, 2, 3}); got != 2 {
+		t.Errorf("mean = %v, want 2", got)
+	}
+}
+
+func TestVariance(t *testing.T) {
+	if got := variance([]int32{2, 4, 4, 4, 5, 5, 7, 9}); got != 4 {
+		t.Errorf("variance = %v, want 4", got)
+	}
+	if got := variance([]int32{10, 10, 10}); got != 0 {
+		t.Errorf("variance of identical samples = %v, want 0", got)
+	}
+}
+
+func TestLastVariance(t *testing.T) {
+	tt := &tank{}
+	if got := tt.LastVariance(); got != 0 {
+		t.Errorf("LastVariance() before any Read = %v, want 0", got)
+	}
+	tt.lastVariance = 12.5
+	if got := tt.LastVariance(); got != 12.5 {
+		t.Errorf("LastVariance() = %v, want 12.5", got)
+	}
+}
+
+func TestRejectOutliersZeroMAD(t *testing.T) {
+	tt := &tank{fullDist: 0, emptyDist: 1000}
+	survivors := tt.rejectOutliers([]int32{500, 500, 500, 900})
+	if len(survivors) != 4 {
+		t.Errorf("rejectOutliers with mad==0 should pass every in-bounds sample through, got %v", survivors)
+	}
+}
+
+func TestNoSurvivorsCause(t *testing.T) {
+	cases := []struct {
+		name    string
+		samples []int32
+		want    error
+	}{
+		{"every sample is a ping timeout", []int32{-1, -1, -1}, ErrPingTimeout},
+		{"samples came back but out of calibrated range", []int32{0, 1, 2}, ErrUnstable},
+		{"mix of timeouts and out-of-range samples is still unstable", []int32{-1, 1, -1}, ErrUnstable},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := noSurvivorsCause(c.samples); got != c.want {
+				t.Errorf("noSurvivorsCause(%v) = %v, want %v", c.samples, got, c.want)
+			}
+		})
+	}
+}
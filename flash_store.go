@@ -0,0 +1,49 @@
+package echo
+
+import "machine"
+
+// flashEraseBlockSize is the erase granularity assumed for the dedicated
+// calibration region; it must match the target's machine.Flash block size.
+const flashEraseBlockSize = 4096
+
+// flashSlotCount bounds how many distinct tank names a FlashStore can back
+// without hashing two names into the same block.
+const flashSlotCount = 16
+
+// FlashStore is a CalibrationStore backed by the TinyGo machine.Flash block
+// device. Each tank's record gets its own dedicated erase block, hashed
+// from its name, within a fixed-size region starting at baseBlock, since
+// flash requires an erase before every write.
+type FlashStore struct {
+	dev       machine.Flash
+	baseBlock int64
+}
+
+// NewFlashStore returns a FlashStore whose records live in dev starting at baseBlock.
+func NewFlashStore(dev machine.Flash, baseBlock int64) *FlashStore {
+	return &FlashStore{dev: dev, baseBlock: baseBlock}
+}
+
+// slotBlock returns the erase block index of name's record.
+func (s *FlashStore) slotBlock(name string) int64 {
+	return s.baseBlock + slotFor(name, flashSlotCount)
+}
+
+// Load reads and validates name's calibration record from flash.
+func (s *FlashStore) Load(name string) (fullDist, emptyDist int32, err error) {
+	buf := make([]byte, recordSize)
+	if _, err := s.dev.ReadAt(buf, s.slotBlock(name)*flashEraseBlockSize); err != nil {
+		return 0, 0, err
+	}
+	return decodeRecord(name, buf)
+}
+
+// Save erases name's calibration block and writes its new calibration record.
+func (s *FlashStore) Save(name string, fullDist, emptyDist int32) error {
+	block := s.slotBlock(name)
+	if err := s.dev.EraseBlocks(block, 1); err != nil {
+		return err
+	}
+	_, err := s.dev.WriteAt(encodeRecord(name, fullDist, emptyDist), block*flashEraseBlockSize)
+	return err
+}
@@ -0,0 +1,69 @@
+package echo
+
+import (
+	"errors"
+	"machine"
+	"time"
+)
+
+// maxPingWait bounds how long pingMicroseconds will wait for the echo pulse
+// to start or end. The HC-SR04's usable range tops out around 4m, which at
+// the slowest realistic speed of sound (about 313m/s at -30C) is well under
+// a 30ms round trip;
+// anything longer means no echo is coming back (out of range, disconnected
+// pin, dead sensor).
+const maxPingWait = 30 * time.Millisecond
+
+// ErrPingTimeout is returned when the echo pulse never arrives within maxPingWait.
+var ErrPingTimeout = errors.New("echo: ping timed out waiting for echo pulse")
+
+// pingMicroseconds triggers the HC-SR04 and returns the round-trip echo
+// pulse width in microseconds. This bypasses tinygo.org/x/drivers/hcsr04's
+// ReadDistance(), which bakes in a fixed 340m/s speed of sound; echo needs
+// the raw time-of-flight so it can apply its own temperature-compensated
+// conversion in speedOfSound(). Both wait loops are bounded by maxPingWait
+// so a missing echo (out of range, disconnected pin, dead sensor) returns
+// ErrPingTimeout instead of hanging forever.
+func (t *tank) pingMicroseconds() (int64, error) {
+	t.trig.Low()
+	time.Sleep(2 * time.Microsecond)
+	t.trig.High()
+	time.Sleep(10 * time.Microsecond)
+	t.trig.Low()
+
+	deadline := time.Now().Add(maxPingWait)
+	for !t.echo.Get() {
+		if time.Now().After(deadline) {
+			return 0, ErrPingTimeout
+		}
+	}
+	start := time.Now()
+	for t.echo.Get() {
+		if time.Since(start) > maxPingWait {
+			return 0, ErrPingTimeout
+		}
+	}
+	return time.Since(start).Microseconds(), nil
+}
+
+// readDistance pings the HC-SR04 and converts the raw pulse width to a
+// distance in millimeters, using the speed of sound for the tank's current
+// environment (see speedOfSound and EnvSensor).
+func (t *tank) readDistance() (int32, error) {
+	us, err := t.pingMicroseconds()
+	if err != nil {
+		return 0, err
+	}
+	tempC, humidityPct, pressureKPa := t.readings()
+	speed := speedOfSound(tempC, humidityPct, pressureKPa) // m/s
+
+	seconds := float64(us) / 1e6
+	mm := (seconds * speed / 2) * 1000
+	return int32(mm), nil
+}
+
+// configurePins sets up the trigger and echo pins for direct GPIO control
+func configurePins(trig, echo machine.Pin) {
+	trig.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	echo.Configure(machine.PinConfig{Mode: machine.PinInput})
+}
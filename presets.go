@@ -0,0 +1,163 @@
+package echo
+
+import (
+	"fmt"
+	"machine"
+	"math"
+	"sort"
+)
+
+// Presets is a catalog of common vessel shapes and dimensions, keyed by a
+// human-readable name, for quickly configuring or identifying a tank
+// without measuring it by hand. Dimensions are nominal manufacturer figures,
+// not exact specs for any one vendor's tank.
+var Presets = map[string]TankConf{
+	"55gal drum": {Shape: Cylinder, R: 11.25 * Inch, H: 33.5 * Inch},
+
+	"IBC tote 275gal": {Shape: Cuboid, S1: 40 * Inch, S2: 48 * Inch, H: 46 * Inch},
+	"IBC tote 330gal": {Shape: Cuboid, S1: 40 * Inch, S2: 48 * Inch, H: 53 * Inch},
+
+	"propane 20lb": {Shape: Cylinder, R: 6.25 * Inch, H: 18 * Inch},
+	"propane 30lb": {Shape: Cylinder, R: 6.25 * Inch, H: 24 * Inch},
+	"propane 40lb": {Shape: Cylinder, R: 7.25 * Inch, H: 29 * Inch},
+
+	"RV fresh water": {Shape: Cuboid, S1: 30 * Inch, S2: 24 * Inch, H: 12 * Inch},
+	"RV gray water":  {Shape: Cuboid, S1: 24 * Inch, S2: 20 * Inch, H: 12 * Inch},
+	"RV black water": {Shape: Cuboid, S1: 20 * Inch, S2: 20 * Inch, H: 12 * Inch},
+
+	"water heater 40gal": {Shape: Cylinder, R: 10 * Inch, H: 48.5 * Inch},
+	"water heater 50gal": {Shape: Cylinder, R: 10 * Inch, H: 60 * Inch},
+	"water heater 75gal": {Shape: Cylinder, R: 12 * Inch, H: 55 * Inch},
+	"water heater 80gal": {Shape: Cylinder, R: 12 * Inch, H: 60 * Inch},
+}
+
+// NewFromPreset returns a Tank configured from a named entry in Presets.
+func NewFromPreset(trig, echo machine.Pin, presetName string) (Tank, error) {
+	tc, ok := Presets[presetName]
+	if !ok {
+		return nil, fmt.Errorf("echo: no such preset %q", presetName)
+	}
+	t := New(trig, echo)
+	t.Configure(tc)
+	return t, nil
+}
+
+// MatchPreset scores t's configured shape, dimensions, and calibrated
+// capacity against the Presets catalog and returns the closest match's name
+// along with a confidence in [0,1]. It returns ("", 0) if no preset shares
+// t's shape at all (e.g. no Sphere is in the catalog). Preset names are
+// tried in sorted order so a tie between two equally-good matches always
+// resolves to the same (alphabetically first) name, rather than depending
+// on Go's randomized map iteration order.
+func MatchPreset(t Tank) (name string, confidence float64) {
+	tt, ok := t.(*tank)
+	if !ok {
+		return "", 0
+	}
+
+	names := make([]string, 0, len(Presets))
+	for presetName := range Presets {
+		names = append(names, presetName)
+	}
+	sort.Strings(names)
+
+	found := false
+	best := 0.0
+	for _, presetName := range names {
+		score, ok := scorePreset(tt, Presets[presetName])
+		if !ok {
+			continue
+		}
+		if !found || score > best {
+			found = true
+			best = score
+			name = presetName
+		}
+	}
+	if !found {
+		return "", 0
+	}
+	return name, best
+}
+
+// scorePreset returns a [0,1] confidence that tc describes t, combining
+// dimensional similarity with calibrated-capacity similarity. ok is false
+// when tc's shape doesn't match t's at all, meaning tc isn't a candidate.
+func scorePreset(t *tank, tc TankConf) (score float64, ok bool) {
+	if t.shape != tc.Shape {
+		return 0, false
+	}
+	preset := &tank{shape: tc.Shape, r: tc.R, h: tc.H, s1: tc.S1, s2: tc.S2, length: tc.Length, r1: tc.R1, r2: tc.R2, theta: tc.Theta}
+	return (dimensionSimilarity(t, preset) + capacitySimilarity(t, preset)) / 2, true
+}
+
+// dimensionSimilarity averages the per-dimension similarity of whichever
+// fields are relevant to a's shape.
+func dimensionSimilarity(a, b *tank) float64 {
+	var dims [][2]float64
+	switch a.shape {
+	case Sphere:
+		dims = append(dims, [2]float64{float64(a.r), float64(b.r)})
+	case Cylinder:
+		dims = append(dims, [2]float64{float64(a.r), float64(b.r)}, [2]float64{float64(a.h), float64(b.h)})
+	case HorizontalCylinder:
+		dims = append(dims, [2]float64{float64(a.r), float64(b.r)}, [2]float64{float64(a.length), float64(b.length)})
+	case Cuboid:
+		dims = append(dims, [2]float64{float64(a.s1), float64(b.s1)}, [2]float64{float64(a.s2), float64(b.s2)}, [2]float64{float64(a.h), float64(b.h)})
+	case Cone:
+		dims = append(dims, [2]float64{float64(a.h), float64(b.h)}, [2]float64{a.theta, b.theta})
+	case Frustum:
+		dims = append(dims, [2]float64{float64(a.h), float64(b.h)}, [2]float64{float64(a.r1), float64(b.r1)}, [2]float64{float64(a.r2), float64(b.r2)})
+	}
+	if len(dims) == 0 {
+		return 0
+	}
+	var total float64
+	for _, d := range dims {
+		total += similarity(d[0], d[1])
+	}
+	return total / float64(len(dims))
+}
+
+// capacitySimilarity compares t's calibrated capacity (falling back to its
+// geometric capacity if uncalibrated) against preset's geometric capacity.
+func capacitySimilarity(t, preset *tank) float64 {
+	calibrated := t.capacity()
+	if t.fullDist != 0 && t.emptyDist != 0 {
+		calibrated = t.volume(t.fullDist)
+	}
+	return similarity(float64(calibrated), float64(preset.capacity()))
+}
+
+// capacity estimates a tank's total volume directly from its configured
+// dimensions, independent of any sensor calibration.
+func (t *tank) capacity() Volume {
+	r, s1, s2, h := float64(t.r), float64(t.s1), float64(t.s2), float64(t.h)
+	var ml float64
+	switch t.shape {
+	case Cylinder:
+		ml = math.Pow(r, 2.0) * math.Pi * h
+	case Sphere:
+		ml = (4.0 / 3.0) * math.Pi * math.Pow(r, 3)
+	case Cuboid:
+		ml = s1 * s2 * h
+	case HorizontalCylinder:
+		length := float64(t.length)
+		ml = math.Pi * math.Pow(r, 2) * length
+	case Cone:
+		ml = math.Pi * math.Pow(h, 3) * math.Pow(math.Tan(t.theta), 2) / 3
+	case Frustum:
+		r1, r2 := float64(t.r1), float64(t.r2)
+		ml = (math.Pi * h / 3) * (math.Pow(r1, 2) + r1*r2 + math.Pow(r2, 2))
+	}
+	return Volume(ml)
+}
+
+// similarity returns 1 when a == b, decaying toward 0 as their relative difference grows.
+func similarity(a, b float64) float64 {
+	if a == 0 && b == 0 {
+		return 1
+	}
+	diff := math.Abs(a-b) / math.Max(math.Abs(a), math.Abs(b))
+	return math.Max(0, 1-diff)
+}
@@ -0,0 +1,27 @@
+package echo
+
+import "testing"
+
+func TestMatchPresetNoCandidateForShape(t *testing.T) {
+	tt := &tank{shape: Sphere, r: 50 * Centimeter}
+	if name, confidence := MatchPreset(tt); name != "" || confidence != 0 {
+		t.Errorf("MatchPreset for a shape with no catalog entry = (%q, %v), want (\"\", 0)", name, confidence)
+	}
+}
+
+// A zero-value tank defaults to Cylinder, which does have catalog
+// candidates, so it should report one of them rather than "no match" -
+// unlike a shape with no candidates at all, covered above.
+func TestMatchPresetUnconfiguredTank(t *testing.T) {
+	tt := &tank{}
+	if name, _ := MatchPreset(tt); name == "" {
+		t.Errorf("MatchPreset for a zero-value (Cylinder) tank returned no match, want a Cylinder preset")
+	}
+}
+
+func TestScorePresetShapeMismatch(t *testing.T) {
+	tt := &tank{shape: Cone, h: 10 * Centimeter}
+	if _, ok := scorePreset(tt, TankConf{Shape: Cylinder, R: 10 * Centimeter, H: 10 * Centimeter}); ok {
+		t.Errorf("scorePreset across mismatched shapes should report ok=false")
+	}
+}
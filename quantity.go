@@ -0,0 +1,86 @@
+package echo
+
+import "strconv"
+
+// Length is a distance, stored internally as centimeters. Typed constants
+// below let a caller express a dimension in whatever unit is convenient,
+// e.g. 10 * echo.Inch, without echo ever having to track which unit a raw
+// number was given in.
+type Length float64
+
+const (
+	Millimeter Length = 0.1
+	Centimeter Length = 1.0
+	Meter      Length = 100.0
+	Inch       Length = 2.54
+	Foot       Length = 30.48
+)
+
+// As returns l expressed as a multiple of unit, e.g. (10*Inch).As(Centimeter) == 25.4
+func (l Length) As(unit Length) float64 {
+	return float64(l) / float64(unit)
+}
+
+// Format returns l as a multiple of unit with that unit's suffix, e.g. "25.40cm"
+func (l Length) Format(unit Length) string {
+	return strconv.FormatFloat(l.As(unit), 'f', 2, 64) + unit.suffix()
+}
+
+// suffix returns the textual unit suffix for one of the Length constants above
+func (l Length) suffix() string {
+	switch l {
+	case Millimeter:
+		return "mm"
+	case Meter:
+		return "m"
+	case Inch:
+		return "in"
+	case Foot:
+		return "ft"
+	default: // Centimeter
+		return "cm"
+	}
+}
+
+// Volume is a capacity, stored internally as milliliters (aka cubic
+// centimeters). As with Length, typed constants let a caller work in
+// whatever unit is convenient and pick a display unit at print time via As
+// or Format, rather than echo tracking a preferred unit alongside the value.
+type Volume float64
+
+const (
+	Milliliter Volume = 1.0
+	Liter      Volume = 1000.0
+	Ounce      Volume = 1 / 0.0338
+	Pint       Volume = 1 / 0.002113
+	Quart      Volume = 1 / 0.001057
+	Gallon     Volume = 1 / 0.000264172
+)
+
+// As returns v expressed as a multiple of unit, e.g. (1*Gallon).As(Liter) ≈ 3.785
+func (v Volume) As(unit Volume) float64 {
+	return float64(v) / float64(unit)
+}
+
+// Format returns v as a multiple of unit with that unit's suffix, e.g. "3.79L"
+func (v Volume) Format(unit Volume) string {
+	return strconv.FormatFloat(v.As(unit), 'f', 2, 64) + unit.suffix()
+}
+
+// suffix returns the textual unit suffix for one of the Volume constants above
+func (v Volume) suffix() string {
+	switch v {
+	case Liter:
+		return "L"
+	case Ounce:
+		return "oz"
+	case Pint:
+		return "pt"
+	case Quart:
+		return "qt"
+	case Gallon:
+		return "gal"
+	default: // Milliliter
+		return "mL"
+	}
+}